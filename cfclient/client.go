@@ -0,0 +1,238 @@
+// Package cfclient wraps github.com/cloudflare/cloudflare-go with the
+// pagination, retry and error-surfacing behavior go-cf-daemon needs: it
+// pages through zones and DNS records instead of trusting the first page,
+// retries 429/5xx responses with exponential backoff, and returns
+// Cloudflare's own error array instead of just the HTTP status line.
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/The-Ham-Mafia/go-cf-daemon/metrics"
+)
+
+const (
+	maxRetries  = 5
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 30 * time.Second
+)
+
+// Client is a thin, retrying wrapper around cloudflare.API.
+type Client struct {
+	api *cloudflare.API
+}
+
+// New builds a Client authenticated with a Cloudflare API token.
+func New(token string) (*Client, error) {
+	api, err := cloudflare.NewWithAPIToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("cfclient: %w", err)
+	}
+	return &Client{api: api}, nil
+}
+
+// ZoneID resolves a zone name to its Cloudflare zone ID.
+func (c *Client) ZoneID(ctx context.Context, name string) (string, error) {
+	var id string
+	err := observe("zone_id", func() error {
+		return withRetry(ctx, func() error {
+			var err error
+			id, err = c.api.ZoneIDByName(name)
+			return err
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("cfclient: resolve zone %q: %w", name, err)
+	}
+	return id, nil
+}
+
+// ListRecords returns every DNS record of the given type/name in a zone,
+// paging through results until ResultInfo reports all pages fetched.
+func (c *Client) ListRecords(ctx context.Context, zoneID, recordType, name string) ([]cloudflare.DNSRecord, error) {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+
+	var all []cloudflare.DNSRecord
+	err := observe("list_records", func() error {
+		page := 1
+		for {
+			params := cloudflare.ListDNSRecordsParams{
+				Type: recordType,
+				Name: name,
+				ResultInfo: cloudflare.ResultInfo{
+					Page:    page,
+					PerPage: 100,
+				},
+			}
+
+			var (
+				records []cloudflare.DNSRecord
+				info    *cloudflare.ResultInfo
+			)
+			err := withRetry(ctx, func() error {
+				var err error
+				records, info, err = c.api.ListDNSRecords(ctx, rc, params)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			all = append(all, records...)
+			if info == nil || page*info.PerPage >= info.Total {
+				return nil
+			}
+			page++
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cfclient: list records: %w", err)
+	}
+	return all, nil
+}
+
+// CreateRecord creates a DNS record and returns its ID.
+func (c *Client) CreateRecord(ctx context.Context, zoneID string, rec RecordSpec) (string, error) {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	params := rec.toCreateParams()
+
+	var resp cloudflare.DNSRecord
+	err := observe("create_record", func() error {
+		return withRetry(ctx, func() error {
+			var err error
+			resp, err = c.api.CreateDNSRecord(ctx, rc, params)
+			return err
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("cfclient: create record %s %s: %w", rec.Type, rec.Name, err)
+	}
+	return resp.ID, nil
+}
+
+// UpdateRecord overwrites the content of an existing DNS record.
+func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID string, rec RecordSpec) error {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	params := rec.toUpdateParams(recordID)
+
+	err := observe("update_record", func() error {
+		return withRetry(ctx, func() error {
+			_, err := c.api.UpdateDNSRecord(ctx, rc, params)
+			return err
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("cfclient: update record %s %s: %w", rec.Type, rec.Name, err)
+	}
+	return nil
+}
+
+// DeleteRecord removes a DNS record by ID.
+func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID string) error {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	err := observe("delete_record", func() error {
+		return withRetry(ctx, func() error {
+			return c.api.DeleteDNSRecord(ctx, rc, recordID)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("cfclient: delete record %s: %w", recordID, err)
+	}
+	return nil
+}
+
+// observe wraps fn with the cfdaemon_cloudflare_api_requests_total counter
+// and cfdaemon_cloudflare_request_duration_seconds histogram, labeled by
+// the logical method name (not the underlying HTTP verb, since a single
+// call here may retry several HTTP requests).
+func observe(method string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	metrics.CloudflareRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	metrics.CloudflareAPIRequestsTotal.WithLabelValues(method, status).Inc()
+	return err
+}
+
+// withRetry runs fn, retrying on rate limit (429) and server errors with
+// exponential backoff. cloudflare-go doesn't surface the Retry-After header
+// on RatelimitError, so 429s back off on the same curve as 5xxs rather than
+// honoring the server's requested wait verbatim.
+func withRetry(ctx context.Context, fn func() error) error {
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !isRetryable(err) {
+			return wrapCFError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoffFor(attempt)):
+		}
+	}
+	return fmt.Errorf("cfclient: giving up after %d attempts: %w", maxRetries, lastErr)
+}
+
+// isRetryable reports whether err is a Cloudflare rate-limit or server error,
+// the two cases cloudflare-go models as distinct error types.
+func isRetryable(err error) bool {
+	var rl *cloudflare.RatelimitError
+	if errors.As(err, &rl) {
+		return true
+	}
+	var svc *cloudflare.ServiceError
+	return errors.As(err, &svc)
+}
+
+func backoffFor(attempt int) time.Duration {
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempt)))
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+// cfErrorMessages is implemented by every one of cloudflare-go's per-status
+// error types (RequestError, RatelimitError, ServiceError, ...).
+type cfErrorMessages interface {
+	ErrorMessages() []string
+}
+
+// wrapCFError flattens Cloudflare's error array into the returned error so
+// callers see every reported message, not just the HTTP status line.
+func wrapCFError(err error) error {
+	var cfErr cfErrorMessages
+	if !errors.As(err, &cfErr) {
+		return err
+	}
+	msgs := cfErr.ErrorMessages()
+	if len(msgs) == 0 {
+		return err
+	}
+	return fmt.Errorf("cloudflare api error: %s", joinMessages(msgs))
+}
+
+func joinMessages(msgs []string) string {
+	out := msgs[0]
+	for _, m := range msgs[1:] {
+		out += "; " + m
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package cfclient
+
+import "github.com/cloudflare/cloudflare-go"
+
+// RecordSpec describes the DNS record go-cf-daemon wants published. Unlike
+// the old type-agnostic "content" string, each record type gets its own
+// typed fields so callers can't hand MX a bare string and lose priority.
+type RecordSpec struct {
+	Name    string
+	Type    string
+	TTL     int
+	Proxied bool
+
+	// Content is used as-is for simple types: A, AAAA, CNAME, NS, TXT
+	// (pre-quoted by the caller if it needs to be split).
+	Content string
+
+	MX  *MXContent
+	SRV *SRVContent
+	CAA *CAAContent
+}
+
+// MXContent is the typed payload for an MX record.
+type MXContent struct {
+	Priority uint16
+	Target   string
+}
+
+// SRVContent is the typed payload for an SRV record.
+type SRVContent struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+// CAAContent is the typed payload for a CAA record.
+type CAAContent struct {
+	Flags uint8
+	Tag   string
+	Value string
+}
+
+func (r RecordSpec) toCreateParams() cloudflare.CreateDNSRecordParams {
+	p := cloudflare.CreateDNSRecordParams{
+		Type:    r.Type,
+		Name:    r.Name,
+		Proxied: &r.Proxied,
+		TTL:     r.ttl(),
+	}
+	r.applyContent(&p.Content, &p.Priority, &p.Data)
+	return p
+}
+
+func (r RecordSpec) toUpdateParams(recordID string) cloudflare.UpdateDNSRecordParams {
+	p := cloudflare.UpdateDNSRecordParams{
+		ID:      recordID,
+		Type:    r.Type,
+		Name:    r.Name,
+		Proxied: &r.Proxied,
+		TTL:     r.ttl(),
+	}
+	r.applyContent(&p.Content, &p.Priority, &p.Data)
+	return p
+}
+
+func (r RecordSpec) ttl() int {
+	if r.Proxied {
+		return 1
+	}
+	if r.TTL > 0 {
+		return r.TTL
+	}
+	return 300
+}
+
+// applyContent fills in the record-type-specific fields of the create/update
+// params, mirroring how cloudflare-go expects MX/SRV/CAA to be shaped.
+func (r RecordSpec) applyContent(content *string, priority **uint16, data *interface{}) {
+	switch r.Type {
+	case "MX":
+		if r.MX != nil {
+			*content = r.MX.Target
+			p := r.MX.Priority
+			*priority = &p
+		}
+	case "SRV":
+		if r.SRV != nil {
+			*data = map[string]interface{}{
+				"priority": r.SRV.Priority,
+				"weight":   r.SRV.Weight,
+				"port":     r.SRV.Port,
+				"target":   r.SRV.Target,
+			}
+		}
+	case "CAA":
+		if r.CAA != nil {
+			*data = map[string]interface{}{
+				"flags": r.CAA.Flags,
+				"tag":   r.CAA.Tag,
+				"value": r.CAA.Value,
+			}
+		}
+	default:
+		*content = r.Content
+	}
+}
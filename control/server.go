@@ -0,0 +1,122 @@
+// Package control exposes a small bearer-token-gated HTTP API so the
+// daemon can be reloaded, resynced and inspected without a restart -
+// restarting currently loses every cached zone/record ID and re-queries
+// Cloudflare for all of them.
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Daemon is the subset of the main daemon's behavior the control API
+// drives. It's implemented by the daemon type in package main.
+type Daemon interface {
+	Status() Status
+	Reload() error
+	Sync()
+	Purge(zone, record string) error
+}
+
+// Status is the JSON body returned by GET /status.
+type Status struct {
+	IPv4  string                `json:"ipv4"`
+	IPv6  string                `json:"ipv6,omitempty"`
+	Zones map[string]ZoneStatus `json:"zones"`
+}
+
+// ZoneStatus reports a zone's cached ID and its records' cached state.
+type ZoneStatus struct {
+	ZoneID  string                  `json:"zone_id"`
+	Records map[string]RecordStatus `json:"records"`
+}
+
+// RecordStatus reports a record's cached ID and when it was last updated.
+type RecordStatus struct {
+	RecordID   string `json:"record_id"`
+	LastUpdate string `json:"last_update,omitempty"`
+}
+
+// Server wires a Daemon up to the control HTTP API.
+type Server struct {
+	daemon Daemon
+	token  string
+}
+
+// New builds a Server. token gates every request via a bearer
+// Authorization header; an empty token refuses all requests rather than
+// leaving the API open.
+func New(daemon Daemon, token string) *Server {
+	return &Server{daemon: daemon, token: token}
+}
+
+// Handler returns the authenticated http.Handler for the control API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/reload", s.handleReload)
+	mux.HandleFunc("/sync", s.handleSync)
+	mux.HandleFunc("/records/", s.handlePurge)
+	return s.withAuth(mux)
+}
+
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" || r.Header.Get("Authorization") != "Bearer "+s.token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.daemon.Status())
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.daemon.Reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.daemon.Sync()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handlePurge serves POST /records/{zone}/{name}/purge.
+func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/records/"), "/"), "/")
+	if len(parts) != 3 || parts[2] != "purge" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := s.daemon.Purge(parts[0], parts[1]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package dnsdisc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/The-Ham-Mafia/go-cf-daemon/cfclient"
+)
+
+// Sync brings the zone's published TXT records in line with the tree built
+// from t. It lists the zone's existing TXT records once, diffs them against
+// the desired set, and issues only the create/update/delete calls needed.
+func Sync(ctx context.Context, cf *cfclient.Client, zoneID, zoneName string, t Tree) error {
+	tree, err := Build(t)
+	if err != nil {
+		return err
+	}
+	desired := ToRecords(tree, t.Domain)
+
+	existing, err := cf.ListRecords(ctx, zoneID, "TXT", "")
+	if err != nil {
+		return fmt.Errorf("dnsdisc: list existing TXT records: %w", err)
+	}
+
+	creates, updates, deletes := diffRecords(t.Domain, desired, existing)
+
+	for name, content := range creates {
+		spec := cfclient.RecordSpec{Name: name, Type: "TXT", Content: content}
+		if _, err := cf.CreateRecord(ctx, zoneID, spec); err != nil {
+			return fmt.Errorf("dnsdisc: create %s: %w", name, err)
+		}
+	}
+	for name, u := range updates {
+		spec := cfclient.RecordSpec{Name: name, Type: "TXT", Content: u.content}
+		if err := cf.UpdateRecord(ctx, zoneID, u.id, spec); err != nil {
+			return fmt.Errorf("dnsdisc: update %s: %w", name, err)
+		}
+	}
+	for _, d := range deletes {
+		if err := cf.DeleteRecord(ctx, zoneID, d.id); err != nil {
+			return fmt.Errorf("dnsdisc: delete stale %s: %w", d.name, err)
+		}
+	}
+
+	return nil
+}
+
+// publishedRecord is an existing TXT record we recognize as one of ours.
+type publishedRecord struct {
+	id      string
+	content string
+}
+
+// recordUpdate is a published record whose content needs to change to
+// match the desired tree.
+type recordUpdate struct {
+	id      string
+	content string
+}
+
+// recordDelete identifies a published record that's no longer part of the
+// desired tree and should be removed.
+type recordDelete struct {
+	id   string
+	name string
+}
+
+// diffRecords compares the desired TXT records against what's already
+// published under domain and returns the create/update/delete calls needed
+// to reconcile them. Only records that look like our own enrtree encoding
+// are considered "published" - an unrelated TXT record that happens to
+// live under the discovery subdomain (e.g. _acme-challenge) is left alone.
+func diffRecords(domain string, desired map[string]string, existing []cloudflare.DNSRecord) (creates map[string]string, updates map[string]recordUpdate, deletes []recordDelete) {
+	published := make(map[string]publishedRecord)
+	suffix := "." + domain
+	for _, rec := range existing {
+		if rec.Name != domain && !strings.HasSuffix(rec.Name, suffix) {
+			continue
+		}
+		if !isEnrtreeContent(rec.Content) {
+			continue
+		}
+		published[rec.Name] = publishedRecord{id: rec.ID, content: rec.Content}
+	}
+
+	creates = make(map[string]string)
+	updates = make(map[string]recordUpdate)
+	for name, content := range desired {
+		if cur, ok := published[name]; ok {
+			if cur.content != content {
+				updates[name] = recordUpdate{id: cur.id, content: content}
+			}
+			delete(published, name)
+			continue
+		}
+		creates[name] = content
+	}
+
+	// Anything left over in published is stale (pruned leaf/branch nodes
+	// from a previous tree) and should be removed.
+	for name, rec := range published {
+		deletes = append(deletes, recordDelete{id: rec.id, name: name})
+	}
+	return creates, updates, deletes
+}
+
+// enrtree content prefixes, matching go-ethereum's p2p/dnsdisc encoding of
+// root, branch, link, and leaf (node record) entries.
+const (
+	enrtreeRootPrefix   = "enrtree-root:v1"
+	enrtreeBranchPrefix = "enrtree-branch:"
+	enrtreeLinkPrefix   = "enrtree://"
+	enrPrefix           = "enr:"
+)
+
+// isEnrtreeContent reports whether content looks like one of our own
+// enrtree-encoded TXT records, as opposed to an unrelated TXT record that
+// happens to live under the discovery subdomain.
+func isEnrtreeContent(content string) bool {
+	switch {
+	case strings.HasPrefix(content, enrtreeRootPrefix):
+		return true
+	case strings.HasPrefix(content, enrtreeBranchPrefix):
+		return true
+	case strings.HasPrefix(content, enrtreeLinkPrefix):
+		return true
+	case strings.HasPrefix(content, enrPrefix):
+		return true
+	default:
+		return false
+	}
+}
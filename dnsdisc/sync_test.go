@@ -0,0 +1,77 @@
+package dnsdisc
+
+import (
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestDiffRecordsCreateUpdateDelete(t *testing.T) {
+	domain := "nodes.example.com"
+	desired := map[string]string{
+		domain:                         "enrtree-root:v1 e=AAAA l=BBBB seq=2 sig=cccc",
+		"deadbeef." + domain:           "enrtree-branch:abcd,efgh",
+		"unchanged.deadbeef." + domain: "enr:unchanged",
+	}
+	existing := []cloudflare.DNSRecord{
+		{ID: "id-root", Name: domain, Content: "enrtree-root:v1 e=AAAA l=BBBB seq=1 sig=oldsig"},
+		{ID: "id-unchanged", Name: "unchanged.deadbeef." + domain, Content: "enr:unchanged"},
+		{ID: "id-stale", Name: "stale." + domain, Content: "enrtree-branch:zzzz"},
+	}
+
+	creates, updates, deletes := diffRecords(domain, desired, existing)
+
+	if len(creates) != 1 || creates["deadbeef."+domain] != "enrtree-branch:abcd,efgh" {
+		t.Errorf("creates = %v, want exactly the new branch record", creates)
+	}
+	if len(updates) != 1 || updates[domain].id != "id-root" || updates[domain].content != desired[domain] {
+		t.Errorf("updates = %v, want the root record updated to the new seq", updates)
+	}
+	if len(deletes) != 1 || deletes[0].id != "id-stale" {
+		t.Errorf("deletes = %v, want exactly the stale branch removed", deletes)
+	}
+}
+
+func TestDiffRecordsIgnoresUnrelatedTXT(t *testing.T) {
+	domain := "nodes.example.com"
+	desired := map[string]string{
+		domain: "enrtree-root:v1 e=AAAA l=BBBB seq=1 sig=cccc",
+	}
+	existing := []cloudflare.DNSRecord{
+		{ID: "id-root", Name: domain, Content: desired[domain]},
+		{ID: "id-acme", Name: "_acme-challenge." + domain, Content: "some-verification-token"},
+		{ID: "id-verify", Name: domain, Content: "google-site-verification=abc123"},
+	}
+
+	creates, updates, deletes := diffRecords(domain, desired, existing)
+
+	if len(creates) != 0 {
+		t.Errorf("creates = %v, want none", creates)
+	}
+	if len(updates) != 0 {
+		t.Errorf("updates = %v, want none (root content unchanged)", updates)
+	}
+	if len(deletes) != 0 {
+		t.Errorf("deletes = %v, want none - unrelated TXT records must not be pruned", deletes)
+	}
+}
+
+func TestIsEnrtreeContent(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"enrtree-root:v1 e=AAAA l=BBBB seq=1 sig=cccc", true},
+		{"enrtree-branch:abcd,efgh", true},
+		{"enrtree://pubkey@nodes.example.com", true},
+		{"enr:-IS4QAAAA", true},
+		{"google-site-verification=abc123", false},
+		{"v=spf1 include:_spf.example.com ~all", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isEnrtreeContent(tt.content); got != tt.want {
+			t.Errorf("isEnrtreeContent(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package dnsdisc publishes an EIP-1459 node-discovery merkle tree as
+// Cloudflare TXT records. Tree construction, signing and the 2KB-aware
+// branch fan-out are all handled by go-ethereum's own p2p/dnsdisc package
+// (the reference implementation of the spec); this package is only
+// responsible for loading the config-driven inputs and reconciling the
+// resulting TXT records against what's currently published.
+package dnsdisc
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Tree describes one [[zone.dnsdisc]] config block: a subdomain to publish
+// under, the ENRs it should advertise, and the key that signs the root.
+type Tree struct {
+	Domain     string // e.g. "nodes.example.com"
+	ENRFile    string // path to a file of newline-separated "enr:..." records
+	KeyFile    string // path to a hex-encoded secp256k1 private key
+	Links      []string
+	Seq        uint
+}
+
+// Build loads the tree's inputs and produces a signed dnsdisc.Tree ready to
+// be flattened into TXT records with ToRecords.
+func Build(t Tree) (*dnsdisc.Tree, error) {
+	nodes, err := loadENRs(t.ENRFile)
+	if err != nil {
+		return nil, fmt.Errorf("dnsdisc: load enrs: %w", err)
+	}
+
+	key, err := loadKey(t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("dnsdisc: load key: %w", err)
+	}
+
+	tree, err := dnsdisc.MakeTree(t.Seq, nodes, t.Links)
+	if err != nil {
+		return nil, fmt.Errorf("dnsdisc: build tree: %w", err)
+	}
+	if _, err := tree.Sign(key, t.Domain); err != nil {
+		return nil, fmt.Errorf("dnsdisc: sign tree: %w", err)
+	}
+	return tree, nil
+}
+
+// ToRecords flattens a signed tree into TXT record names and content, ready
+// for diffing against what's published in Cloudflare. Names are qualified
+// with domain: the root lives at domain itself, every other entry at
+// <hash>.domain.
+func ToRecords(tree *dnsdisc.Tree, domain string) map[string]string {
+	return tree.ToTXT(domain)
+}
+
+func loadENRs(path string) ([]*enode.Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var nodes []*enode.Node
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		node, err := enode.Parse(enode.ValidSchemes, line)
+		if err != nil {
+			return nil, fmt.Errorf("parse enr %q: %w", line, err)
+		}
+		nodes = append(nodes, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func loadKey(path string) (*ecdsa.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.HexToECDSA(strings.TrimSpace(string(raw)))
+}
@@ -0,0 +1,77 @@
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Resolver queries a set of Providers for a family and only trusts the
+// result once at least MinAgreement of them report the same address, so a
+// single flapping or malicious provider can't push a bad update.
+type Resolver struct {
+	Providers    []Provider
+	MinAgreement int
+}
+
+// NewResolver builds a Resolver requiring minAgreement matching providers.
+// A minAgreement <= 0 is treated as 1 (a single provider's answer is enough).
+func NewResolver(providers []Provider, minAgreement int) *Resolver {
+	if minAgreement <= 0 {
+		minAgreement = 1
+	}
+	return &Resolver{Providers: providers, MinAgreement: minAgreement}
+}
+
+// Resolve queries every provider concurrently and returns the address with
+// the most votes, as long as it meets MinAgreement.
+func (r *Resolver) Resolve(ctx context.Context, family Family) (string, error) {
+	if len(r.Providers) == 0 {
+		return "", fmt.Errorf("ipprovider: no providers configured for family %s", family)
+	}
+
+	type result struct {
+		ip  string
+		err error
+	}
+	results := make([]result, len(r.Providers))
+
+	var wg sync.WaitGroup
+	for i, p := range r.Providers {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+			ip, err := p.Lookup(ctx, family)
+			if err != nil {
+				results[i] = result{err: fmt.Errorf("%s: %w", p.Name(), err)}
+				return
+			}
+			results[i] = result{ip: ip.String()}
+		}(i, p)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			continue
+		}
+		votes[res.ip]++
+	}
+
+	var winner string
+	best := 0
+	for ip, count := range votes {
+		if count > best {
+			winner, best = ip, count
+		}
+	}
+
+	if best < r.MinAgreement {
+		return "", fmt.Errorf("ipprovider: no address for family %s reached agreement of %d (best %d, errors: %v)",
+			family, r.MinAgreement, best, errs)
+	}
+	return winner, nil
+}
@@ -0,0 +1,111 @@
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// fakeProvider returns a fixed IP (or error) regardless of family, enough to
+// drive Resolver's voting logic without touching the network.
+type fakeProvider struct {
+	name string
+	ip   net.IP
+	err  error
+}
+
+func (f fakeProvider) Name() string { return f.name }
+
+func (f fakeProvider) Lookup(ctx context.Context, family Family) (net.IP, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.ip, nil
+}
+
+func TestResolverResolve(t *testing.T) {
+	ipA := net.ParseIP("203.0.113.5")
+	ipB := net.ParseIP("203.0.113.9")
+
+	tests := []struct {
+		name         string
+		providers    []Provider
+		minAgreement int
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "majority agrees",
+			providers: []Provider{
+				fakeProvider{name: "a", ip: ipA},
+				fakeProvider{name: "b", ip: ipA},
+				fakeProvider{name: "c", ip: ipB},
+			},
+			minAgreement: 2,
+			want:         ipA.String(),
+		},
+		{
+			name: "quorum not met",
+			providers: []Provider{
+				fakeProvider{name: "a", ip: ipA},
+				fakeProvider{name: "b", ip: ipB},
+			},
+			minAgreement: 2,
+			wantErr:      true,
+		},
+		{
+			name: "single provider with default agreement",
+			providers: []Provider{
+				fakeProvider{name: "a", ip: ipA},
+			},
+			minAgreement: 0,
+			want:         ipA.String(),
+		},
+		{
+			name: "errors don't count toward agreement",
+			providers: []Provider{
+				fakeProvider{name: "a", ip: ipA},
+				fakeProvider{name: "b", err: fmt.Errorf("boom")},
+			},
+			minAgreement: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "no providers",
+			providers:    nil,
+			minAgreement: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(tt.providers, tt.minAgreement)
+			got, err := r.Resolve(context.Background(), IPv4)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got result %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolverDefaultsMinAgreement(t *testing.T) {
+	r := NewResolver(nil, 0)
+	if r.MinAgreement != 1 {
+		t.Errorf("got MinAgreement %d, want 1", r.MinAgreement)
+	}
+	r = NewResolver(nil, -3)
+	if r.MinAgreement != 1 {
+		t.Errorf("got MinAgreement %d, want 1", r.MinAgreement)
+	}
+}
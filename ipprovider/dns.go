@@ -0,0 +1,69 @@
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNSProvider resolves the caller's address via a lookup against a
+// particular resolver, e.g. "A myip.opendns.com @resolver1.opendns.com" or
+// Cloudflare's "TXT whoami.cloudflare @1.1.1.1" trick.
+type DNSProvider struct {
+	name   string
+	server string // "host:port", e.g. "resolver1.opendns.com:53"
+	query  string // e.g. "myip.opendns.com" or "whoami.cloudflare"
+	txt    bool   // true for the Cloudflare whoami-style TXT trick
+}
+
+// NewDNS builds a DNSProvider labeled name that queries query against
+// server. If txt is true, the answer is read from a TXT record instead of
+// A/AAAA.
+func NewDNS(name, server, query string, txt bool) *DNSProvider {
+	return &DNSProvider{name: name, server: server, query: query, txt: txt}
+}
+
+func (p *DNSProvider) Name() string { return p.name }
+
+func (p *DNSProvider) Lookup(ctx context.Context, family Family) (net.IP, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, p.server)
+		},
+	}
+
+	if p.txt {
+		records, err := resolver.LookupTXT(ctx, p.query)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if ip := net.ParseIP(strings.TrimSpace(r)); ip != nil {
+				if valid, err := validateFamily(ip, family); err == nil {
+					return valid, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("ipprovider: no TXT answer for %s matched family %s", p.query, family)
+	}
+
+	ips, err := resolver.LookupIP(ctx, dnsNetwork(family), p.query)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("ipprovider: no answer for %s", p.query)
+	}
+	return validateFamily(ips[0], family)
+}
+
+func dnsNetwork(family Family) string {
+	if family == IPv6 {
+		return "ip6"
+	}
+	return "ip4"
+}
@@ -0,0 +1,55 @@
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxBodyBytes bounds how much of a response we'll read; a plain-text IP
+// response is at most a few dozen bytes, so this is generous headroom.
+const maxBodyBytes = 256
+
+// HTTPSProvider fetches the caller's address from a plain-text endpoint
+// such as api.ipify.org or icanhazip.com. Endpoints are usually family
+// specific (e.g. api4.ipify.org vs api6.ipify.org), so URL should match the
+// family this provider is registered for.
+type HTTPSProvider struct {
+	name string
+	url  string
+}
+
+// NewHTTPS builds an HTTPSProvider labeled name that fetches url.
+func NewHTTPS(name, url string) *HTTPSProvider {
+	return &HTTPSProvider{name: name, url: url}
+}
+
+func (p *HTTPSProvider) Name() string { return p.name }
+
+func (p *HTTPSProvider) Lookup(ctx context.Context, family Family) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ipprovider: %s returned %s", p.url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	return validateFamily(ip, family)
+}
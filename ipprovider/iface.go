@@ -0,0 +1,45 @@
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// InterfaceProvider reads the global address directly off a local NIC,
+// useful when the host itself holds the routed prefix (common for IPv6)
+// rather than sitting behind NAT.
+type InterfaceProvider struct {
+	name string
+	nic  string
+}
+
+// NewInterface builds an InterfaceProvider labeled name that reads nic.
+func NewInterface(name, nic string) *InterfaceProvider {
+	return &InterfaceProvider{name: name, nic: nic}
+}
+
+func (p *InterfaceProvider) Name() string { return p.name }
+
+func (p *InterfaceProvider) Lookup(_ context.Context, family Family) (net.IP, error) {
+	iface, err := net.InterfaceByName(p.nic)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ip, err := validateFamily(ipNet.IP, family); err == nil {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("ipprovider: no global %s address on %s: %w", family, p.nic, ErrUnsupported)
+}
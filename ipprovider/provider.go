@@ -0,0 +1,51 @@
+// Package ipprovider discovers the host's public IP address. Unlike the
+// single hardcoded HTTPS GET it replaces, it supports several discovery
+// methods per address family and can require multiple of them to agree
+// before an address is trusted.
+package ipprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// Family is an IP address family, used to keep A and AAAA discovery
+// independent so one flapping AAAA provider can't affect A records.
+type Family string
+
+const (
+	IPv4 Family = "4"
+	IPv6 Family = "6"
+)
+
+// Provider looks up the host's public address for a given family. A
+// provider that doesn't support a family should return ErrUnsupported.
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, family Family) (net.IP, error)
+}
+
+// ErrUnsupported is returned by a Provider that has no way to answer for
+// the requested family (e.g. an interface with no IPv6 address).
+var ErrUnsupported = fmt.Errorf("ipprovider: family not supported by provider")
+
+func validateFamily(ip net.IP, family Family) (net.IP, error) {
+	if ip == nil {
+		return nil, fmt.Errorf("ipprovider: empty address")
+	}
+	switch family {
+	case IPv4:
+		if v4 := ip.To4(); v4 != nil {
+			return v4, nil
+		}
+		return nil, fmt.Errorf("ipprovider: %s is not an IPv4 address", ip)
+	case IPv6:
+		if ip.To4() == nil && ip.To16() != nil {
+			return ip.To16(), nil
+		}
+		return nil, fmt.Errorf("ipprovider: %s is not an IPv6 address", ip)
+	default:
+		return nil, fmt.Errorf("ipprovider: unknown family %q", family)
+	}
+}
@@ -0,0 +1,184 @@
+package ipprovider
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// stunMagicCookie is the fixed RFC 5389 magic cookie, used both to build
+// the binding request and to XOR-decode the response's mapped address.
+const stunMagicCookie = 0x2112A442
+
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunAttrMappedAddr  = 0x0001
+	stunAttrXorMapped   = 0x0020
+)
+
+// STUN address families, as carried in the MAPPED-ADDRESS/XOR-MAPPED-ADDRESS
+// attribute's family byte.
+const (
+	stunFamilyIPv4 = 0x01
+	stunFamilyIPv6 = 0x02
+)
+
+// STUNProvider discovers the caller's reflexive address via an RFC 5389
+// binding request against one or more STUN servers, trying each in order
+// until one answers.
+type STUNProvider struct {
+	name    string
+	servers []string // "host:port"
+}
+
+// NewSTUN builds a STUNProvider labeled name that tries servers in order.
+func NewSTUN(name string, servers []string) *STUNProvider {
+	return &STUNProvider{name: name, servers: servers}
+}
+
+func (p *STUNProvider) Name() string { return p.name }
+
+func (p *STUNProvider) Lookup(ctx context.Context, family Family) (net.IP, error) {
+	network := "udp4"
+	if family == IPv6 {
+		network = "udp6"
+	}
+
+	var lastErr error
+	for _, server := range p.servers {
+		ip, err := stunRequest(ctx, network, server)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return validateFamily(ip, family)
+	}
+	return nil, fmt.Errorf("ipprovider: all STUN servers failed: %w", lastErr)
+}
+
+func stunRequest(ctx context.Context, network, server string) (net.IP, error) {
+	d := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := make([]byte, 20)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(req[2:4], 0) // message length, no attributes
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	// transaction ID: 12 bytes, zero is fine for a one-shot client request
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseSTUNResponse(resp[:n], req[8:20])
+}
+
+func parseSTUNResponse(resp, transactionID []byte) (net.IP, error) {
+	if len(resp) < 20 {
+		return nil, fmt.Errorf("ipprovider: short STUN response")
+	}
+	msgType := binary.BigEndian.Uint16(resp[0:2])
+	if msgType != stunBindingResponse {
+		return nil, fmt.Errorf("ipprovider: unexpected STUN message type %#x", msgType)
+	}
+
+	msgLen := int(binary.BigEndian.Uint16(resp[2:4]))
+	attrs := resp[20:]
+	if len(attrs) < msgLen {
+		return nil, fmt.Errorf("ipprovider: truncated STUN attributes")
+	}
+	attrs = attrs[:msgLen]
+
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunAttrXorMapped:
+			if ip, err := decodeXorMappedAddress(value, transactionID); err == nil {
+				return ip, nil
+			}
+		case stunAttrMappedAddr:
+			if ip, err := decodeMappedAddress(value); err == nil {
+				return ip, nil
+			}
+		}
+
+		// attributes are padded to a 4-byte boundary
+		advance := 4 + attrLen
+		if pad := attrLen % 4; pad != 0 {
+			advance += 4 - pad
+		}
+		attrs = attrs[advance:]
+	}
+	return nil, fmt.Errorf("ipprovider: no mapped address attribute in STUN response")
+}
+
+func decodeMappedAddress(value []byte) (net.IP, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("ipprovider: short MAPPED-ADDRESS")
+	}
+	switch value[1] {
+	case stunFamilyIPv4:
+		if len(value) < 8 {
+			return nil, fmt.Errorf("ipprovider: short IPv4 MAPPED-ADDRESS")
+		}
+		return net.IP(value[4:8]), nil
+	case stunFamilyIPv6:
+		if len(value) < 20 {
+			return nil, fmt.Errorf("ipprovider: short IPv6 MAPPED-ADDRESS")
+		}
+		return net.IP(value[4:20]), nil
+	default:
+		return nil, fmt.Errorf("ipprovider: unsupported MAPPED-ADDRESS family")
+	}
+}
+
+func decodeXorMappedAddress(value []byte, transactionID []byte) (net.IP, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("ipprovider: short XOR-MAPPED-ADDRESS")
+	}
+
+	cookie := make([]byte, 4)
+	binary.BigEndian.PutUint32(cookie, stunMagicCookie)
+	key := append(cookie, transactionID...)
+
+	switch value[1] {
+	case stunFamilyIPv4:
+		if len(value) < 8 {
+			return nil, fmt.Errorf("ipprovider: short IPv4 XOR-MAPPED-ADDRESS")
+		}
+		addr := make([]byte, 4)
+		for i := range addr {
+			addr[i] = value[4+i] ^ key[i]
+		}
+		return net.IP(addr), nil
+	case stunFamilyIPv6:
+		if len(value) < 20 {
+			return nil, fmt.Errorf("ipprovider: short IPv6 XOR-MAPPED-ADDRESS")
+		}
+		addr := make([]byte, 16)
+		for i := range addr {
+			addr[i] = value[4+i] ^ key[i]
+		}
+		return net.IP(addr), nil
+	default:
+		return nil, fmt.Errorf("ipprovider: unsupported XOR-MAPPED-ADDRESS family")
+	}
+}
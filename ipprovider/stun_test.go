@@ -0,0 +1,144 @@
+package ipprovider
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildSTUNResponse assembles a minimal binding-response message carrying a
+// single attribute, mirroring the wire format stunRequest expects back.
+func buildSTUNResponse(transactionID []byte, attrType uint16, value []byte) []byte {
+	pad := (4 - len(value)%4) % 4
+	attr := make([]byte, 4+len(value)+pad)
+	binary.BigEndian.PutUint16(attr[0:2], attrType)
+	binary.BigEndian.PutUint16(attr[2:4], uint16(len(value)))
+	copy(attr[4:], value)
+
+	msg := make([]byte, 20+len(attr))
+	binary.BigEndian.PutUint16(msg[0:2], stunBindingResponse)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(attr)))
+	binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+	copy(msg[8:20], transactionID)
+	copy(msg[20:], attr)
+	return msg
+}
+
+func xorMappedValue(ip net.IP, port uint16) []byte {
+	cookie := make([]byte, 4)
+	binary.BigEndian.PutUint32(cookie, stunMagicCookie)
+
+	v4 := ip.To4()
+	value := make([]byte, 8)
+	value[1] = stunFamilyIPv4
+	binary.BigEndian.PutUint16(value[2:4], port^binary.BigEndian.Uint16(cookie[0:2]))
+	for i := 0; i < 4; i++ {
+		value[4+i] = v4[i] ^ cookie[i]
+	}
+	return value
+}
+
+func xorMappedValueV6(ip net.IP, port uint16, transactionID []byte) []byte {
+	cookie := make([]byte, 4)
+	binary.BigEndian.PutUint32(cookie, stunMagicCookie)
+	key := append(cookie, transactionID...)
+
+	v6 := ip.To16()
+	value := make([]byte, 20)
+	value[1] = stunFamilyIPv6
+	binary.BigEndian.PutUint16(value[2:4], port^binary.BigEndian.Uint16(cookie[0:2]))
+	for i := 0; i < 16; i++ {
+		value[4+i] = v6[i] ^ key[i]
+	}
+	return value
+}
+
+func TestParseSTUNResponseXorMapped(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	want := net.ParseIP("203.0.113.5").To4()
+	resp := buildSTUNResponse(transactionID, stunAttrXorMapped, xorMappedValue(want, 0))
+
+	got, err := parseSTUNResponse(resp, transactionID)
+	if err != nil {
+		t.Fatalf("parseSTUNResponse: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseSTUNResponseMappedAddress(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	want := net.ParseIP("198.51.100.9").To4()
+	value := make([]byte, 8)
+	value[1] = 0x01 // family: IPv4
+	copy(value[4:8], want)
+	resp := buildSTUNResponse(transactionID, stunAttrMappedAddr, value)
+
+	got, err := parseSTUNResponse(resp, transactionID)
+	if err != nil {
+		t.Fatalf("parseSTUNResponse: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseSTUNResponseErrors(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+
+	tests := []struct {
+		name string
+		resp []byte
+	}{
+		{"short", []byte{0x01, 0x02, 0x03}},
+		{"wrong message type", func() []byte {
+			msg := buildSTUNResponse(transactionID, stunAttrXorMapped, xorMappedValue(net.ParseIP("203.0.113.5"), 0))
+			binary.BigEndian.PutUint16(msg[0:2], stunBindingRequest)
+			return msg
+		}()},
+		{"no mapped address attribute", buildSTUNResponse(transactionID, 0x9999, []byte{0x00, 0x00, 0x00, 0x00})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSTUNResponse(tt.resp, transactionID); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDecodeXorMappedAddress(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	want := net.ParseIP("192.0.2.1").To4()
+
+	got, err := decodeXorMappedAddress(xorMappedValue(want, 4500), transactionID)
+	if err != nil {
+		t.Fatalf("decodeXorMappedAddress: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDecodeXorMappedAddressIPv6(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	want := net.ParseIP("2001:db8::1")
+
+	got, err := decodeXorMappedAddress(xorMappedValueV6(want, 4500, transactionID), transactionID)
+	if err != nil {
+		t.Fatalf("decodeXorMappedAddress: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDecodeXorMappedAddressUnsupportedFamily(t *testing.T) {
+	value := make([]byte, 8)
+	value[1] = 0x03 // neither IPv4 nor IPv6 family marker
+	if _, err := decodeXorMappedAddress(value, []byte("0123456789ab")); err == nil {
+		t.Error("expected error for unsupported family, got nil")
+	}
+}
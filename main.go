@@ -1,34 +1,121 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/BurntSushi/toml"
-	"github.com/fatih/color"
-)
-
-const cfBaseURL = "https://api.cloudflare.com/client/v4"
 
-var (
-	info = color.New(color.FgGreen).PrintlnFunc()
-	errc = color.New(color.FgRed).PrintlnFunc()
+	"github.com/The-Ham-Mafia/go-cf-daemon/cfclient"
+	"github.com/The-Ham-Mafia/go-cf-daemon/control"
+	"github.com/The-Ham-Mafia/go-cf-daemon/dnsdisc"
+	"github.com/The-Ham-Mafia/go-cf-daemon/ipprovider"
+	"github.com/The-Ham-Mafia/go-cf-daemon/metrics"
 )
 
 type Config struct {
-	PollInterval       int    `toml:"poll_interval"`
-	CloudflareAPIToken string `toml:"cloudflare_api_token"`
-	IPProvider         string `toml:"ip_provider"`
-	Zones              []Zone `toml:"zone"`
+	PollInterval       int           `toml:"poll_interval"`
+	CloudflareAPIToken string        `toml:"cloudflare_api_token"`
+	IP                 IPConfig      `toml:"ip"`
+	Zones              []Zone        `toml:"zone"`
+	Metrics            MetricsConfig `toml:"metrics"`
+	Control            ControlConfig `toml:"control"`
+}
+
+// MetricsConfig gates the /metrics HTTP endpoint. Leaving ListenAddr empty
+// disables it.
+type MetricsConfig struct {
+	ListenAddr string `toml:"listen_addr"`
+}
+
+// ControlConfig gates the control-plane HTTP API. Leaving ListenAddr empty
+// disables it.
+type ControlConfig struct {
+	ListenAddr string `toml:"listen_addr"`
+	Token      string `toml:"token"`
+}
+
+// IPConfig lists the providers go-cf-daemon consults for its public IPv4
+// address and how many of them must agree before the result is trusted.
+// IPv6 discovery is configured separately under IPv6, since many hosts have
+// SLAAC temporary addresses that shouldn't be published and so need an
+// explicit opt-in.
+type IPConfig struct {
+	Providers    []ProviderConfig `toml:"providers"`
+	MinAgreement int              `toml:"min_agreement"`
+	IPv6         IPv6Config       `toml:"ipv6"`
+}
+
+// IPv6Config is the opt-in IPv6 counterpart of IPConfig.
+type IPv6Config struct {
+	Enabled      bool             `toml:"enabled"`
+	Providers    []ProviderConfig `toml:"providers"`
+	MinAgreement int              `toml:"min_agreement"`
+}
+
+// ProviderConfig configures one ipprovider.Provider. Only the fields
+// relevant to Type need be set; the rest are ignored.
+type ProviderConfig struct {
+	Type      string   `toml:"type"` // "https", "dns", "stun", "interface"
+	URL       string   `toml:"url"`
+	Server    string   `toml:"server"`
+	Query     string   `toml:"query"`
+	TXT       bool     `toml:"txt"`
+	Servers   []string `toml:"servers"`
+	Interface string   `toml:"interface"`
+}
+
+func buildProviders(cfgs []ProviderConfig) ([]ipprovider.Provider, error) {
+	providers := make([]ipprovider.Provider, 0, len(cfgs))
+	for i, c := range cfgs {
+		name := fmt.Sprintf("%s#%d", c.Type, i)
+		switch c.Type {
+		case "https":
+			providers = append(providers, ipprovider.NewHTTPS(name, c.URL))
+		case "dns":
+			providers = append(providers, ipprovider.NewDNS(name, c.Server, c.Query, c.TXT))
+		case "stun":
+			providers = append(providers, ipprovider.NewSTUN(name, c.Servers))
+		case "interface":
+			providers = append(providers, ipprovider.NewInterface(name, c.Interface))
+		default:
+			return nil, fmt.Errorf("unknown ip provider type %q", c.Type)
+		}
+	}
+	return providers, nil
 }
 
 type Zone struct {
-	Name    string   `toml:"name"`
-	Records []Record `toml:"records"`
+	Name    string          `toml:"name"`
+	Records []Record        `toml:"records"`
+	Dnsdisc []DnsdiscConfig `toml:"dnsdisc"`
+}
+
+// DnsdiscConfig is one [[zone.dnsdisc]] block: a subdomain to publish an
+// EIP-1459 node-discovery tree under.
+type DnsdiscConfig struct {
+	Domain  string   `toml:"domain"`
+	ENRFile string   `toml:"enr_file"`
+	KeyFile string   `toml:"key_file"`
+	Links   []string `toml:"links"`
+	Seq     uint     `toml:"seq"`
+}
+
+func (d DnsdiscConfig) toTree() dnsdisc.Tree {
+	return dnsdisc.Tree{
+		Domain:  d.Domain,
+		ENRFile: d.ENRFile,
+		KeyFile: d.KeyFile,
+		Links:   d.Links,
+		Seq:     d.Seq,
+	}
 }
 
 type Record struct {
@@ -36,24 +123,332 @@ type Record struct {
 	Type    string `toml:"type"`
 	Proxied bool   `toml:"proxied"`
 	Target  string `toml:"target"`
+
+	// Typed fields for record kinds whose content isn't a bare string.
+	Priority uint16 `toml:"priority"`
+	Weight   uint16 `toml:"weight"`
+	Port     uint16 `toml:"port"`
+	Flags    uint8  `toml:"flags"`
+	Tag      string `toml:"tag"`
+	Value    string `toml:"value"`
+}
+
+// toSpec converts the config's Record into the typed cfclient.RecordSpec
+// cfclient needs to build the Cloudflare API request body.
+func (r Record) toSpec(zoneName, ip string) cfclient.RecordSpec {
+	fqdn := recordFQDN(r.Name, zoneName)
+	spec := cfclient.RecordSpec{
+		Name:    fqdn,
+		Type:    r.Type,
+		Proxied: r.Proxied,
+	}
+
+	switch r.Type {
+	case "A", "AAAA":
+		spec.Content = ip
+	case "MX":
+		spec.MX = &cfclient.MXContent{Priority: r.Priority, Target: r.Target}
+	case "SRV":
+		spec.SRV = &cfclient.SRVContent{Priority: r.Priority, Weight: r.Weight, Port: r.Port, Target: r.Target}
+	case "CAA":
+		spec.CAA = &cfclient.CAAContent{Flags: r.Flags, Tag: r.Tag, Value: r.Value}
+	case "TXT":
+		spec.Content = r.Value
+	default:
+		if r.Target != "" {
+			spec.Content = r.Target
+		} else {
+			spec.Content = zoneName
+		}
+	}
+	return spec
 }
 
 type recordCache struct {
-	zoneID   string
-	recordID string
+	zoneID     string
+	recordID   string
+	lastUpdate time.Time
 }
 
+// logInfo and logError emit structured JSON log lines via slog so the
+// daemon's output is straightforward to ingest under a container runtime
+// or systemd.
 func logInfo(msg string) {
-	fmt.Printf("[%s] ", time.Now().Format("2006-01-02 15:04:05"))
-	info(msg)
+	slog.Info(msg)
 }
 
 func logError(msg string) {
-	fmt.Printf("[%s] ", time.Now().Format("2006-01-02 15:04:05"))
-	errc(msg)
+	slog.Error(msg)
+}
+
+// daemon holds everything the poll loop needs and everything the control
+// API reads or mutates. Reload swaps cfg/resolvers under mu; the poll loop
+// and the control API both take mu before touching shared state.
+type daemon struct {
+	mu      sync.Mutex
+	cfgPath string
+	cfg     Config
+
+	cf           *cfclient.Client
+	ipv4Resolver *ipprovider.Resolver
+	ipv6Resolver *ipprovider.Resolver
+
+	cache    map[string]map[string]*recordCache
+	lastIPv4 string
+	lastIPv6 string
+
+	syncCh chan struct{}
+}
+
+func newDaemon(cfgPath string, cfg Config) (*daemon, error) {
+	d := &daemon{
+		cfgPath: cfgPath,
+		cache:   make(map[string]map[string]*recordCache),
+		syncCh:  make(chan struct{}, 1),
+	}
+	if err := d.applyConfig(cfg); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// applyConfig installs cfg as the daemon's active config, rebuilding the
+// Cloudflare client (if the token changed) and the ip resolvers, and
+// reconciling the record cache: new zones/records get empty cache entries,
+// removed ones are dropped, everything else is left alone so a reload
+// doesn't force redundant Cloudflare lookups.
+func (d *daemon) applyConfig(cfg Config) error {
+	for zi := range cfg.Zones {
+		for ri := range cfg.Zones[zi].Records {
+			if cfg.Zones[zi].Records[ri].Type == "" {
+				cfg.Zones[zi].Records[ri].Type = "A"
+			}
+		}
+	}
+
+	ipv4Providers, err := buildProviders(cfg.IP.Providers)
+	if err != nil {
+		return fmt.Errorf("build ip providers: %w", err)
+	}
+	ipv4Resolver := ipprovider.NewResolver(ipv4Providers, cfg.IP.MinAgreement)
+
+	var ipv6Resolver *ipprovider.Resolver
+	if cfg.IP.IPv6.Enabled {
+		ipv6Providers, err := buildProviders(cfg.IP.IPv6.Providers)
+		if err != nil {
+			return fmt.Errorf("build ipv6 providers: %w", err)
+		}
+		ipv6Resolver = ipprovider.NewResolver(ipv6Providers, cfg.IP.IPv6.MinAgreement)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cf == nil || cfg.CloudflareAPIToken != d.cfg.CloudflareAPIToken {
+		cf, err := cfclient.New(cfg.CloudflareAPIToken)
+		if err != nil {
+			return fmt.Errorf("create cloudflare client: %w", err)
+		}
+		d.cf = cf
+	}
+
+	wantZones := make(map[string]bool, len(cfg.Zones))
+	for _, z := range cfg.Zones {
+		wantZones[z.Name] = true
+		zoneCache, ok := d.cache[z.Name]
+		if !ok {
+			zoneCache = make(map[string]*recordCache)
+			d.cache[z.Name] = zoneCache
+		}
+		if _, ok := zoneCache["__zone__"]; !ok {
+			zoneCache["__zone__"] = &recordCache{}
+		}
+
+		wantRecords := make(map[string]bool, len(z.Records))
+		for _, r := range z.Records {
+			wantRecords[r.Name] = true
+			if _, ok := zoneCache[r.Name]; !ok {
+				zoneCache[r.Name] = &recordCache{}
+			}
+		}
+		for name := range zoneCache {
+			if name != "__zone__" && !wantRecords[name] {
+				delete(zoneCache, name)
+			}
+		}
+	}
+	for name := range d.cache {
+		if !wantZones[name] {
+			delete(d.cache, name)
+		}
+	}
+
+	d.cfg = cfg
+	d.ipv4Resolver = ipv4Resolver
+	d.ipv6Resolver = ipv6Resolver
+	return nil
+}
+
+// Reload re-reads cfgPath from disk and applies it. It implements
+// control.Daemon.
+func (d *daemon) Reload() error {
+	var cfg Config
+	if _, err := toml.DecodeFile(d.cfgPath, &cfg); err != nil {
+		return fmt.Errorf("decode config: %w", err)
+	}
+	if err := d.applyConfig(cfg); err != nil {
+		return err
+	}
+	logInfo("Config reloaded")
+	return nil
+}
+
+// Sync requests an immediate poll+update cycle that ignores whether the IP
+// has changed. It implements control.Daemon.
+func (d *daemon) Sync() {
+	select {
+	case d.syncCh <- struct{}{}:
+	default: // a sync is already pending
+	}
+}
+
+// getZoneID returns the cached zone ID for zone, and whether the zone still
+// has a cache entry at all. A reload can drop a zone's cache entry between
+// when poll snapshots the config and when it gets here, so callers must
+// treat ok==false as "skip this zone for now" rather than a bug.
+func (d *daemon) getZoneID(zone string) (zoneID string, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zc, ok := d.cache[zone]
+	if !ok {
+		return "", false
+	}
+	zrc, ok := zc["__zone__"]
+	if !ok {
+		return "", false
+	}
+	return zrc.zoneID, true
+}
+
+// setZoneID stores a looked-up zone ID, returning false if the zone's cache
+// entry was removed (e.g. by a concurrent reload) before the lookup finished.
+func (d *daemon) setZoneID(zone, zoneID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zc, ok := d.cache[zone]
+	if !ok {
+		return false
+	}
+	zrc, ok := zc["__zone__"]
+	if !ok {
+		return false
+	}
+	zrc.zoneID = zoneID
+	return true
+}
+
+// getRecordID returns the cached record ID for zone/name, and whether that
+// cache entry still exists.
+func (d *daemon) getRecordID(zone, name string) (recordID string, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zc, ok := d.cache[zone]
+	if !ok {
+		return "", false
+	}
+	rc, ok := zc[name]
+	if !ok {
+		return "", false
+	}
+	return rc.recordID, true
+}
+
+// setRecordID stores a record ID, returning false if the cache entry was
+// removed (e.g. by a concurrent reload) before the caller finished its work.
+func (d *daemon) setRecordID(zone, name, recordID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zc, ok := d.cache[zone]
+	if !ok {
+		return false
+	}
+	rc, ok := zc[name]
+	if !ok {
+		return false
+	}
+	rc.recordID = recordID
+	return true
+}
+
+// setRecordUpdated stamps the cache entry's lastUpdate time, returning false
+// if the cache entry no longer exists.
+func (d *daemon) setRecordUpdated(zone, name string, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zc, ok := d.cache[zone]
+	if !ok {
+		return false
+	}
+	rc, ok := zc[name]
+	if !ok {
+		return false
+	}
+	rc.lastUpdate = at
+	return true
+}
+
+// Purge invalidates a record's cached ID so the next poll that processes the
+// record looks it up (or recreates it) in Cloudflare instead of trusting the
+// cache. A/AAAA records are only processed when their IP changed or the poll
+// is forced. It implements control.Daemon.
+func (d *daemon) Purge(zone, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	zoneCache, ok := d.cache[zone]
+	if !ok {
+		return fmt.Errorf("zone %q not found", zone)
+	}
+	rc, ok := zoneCache[name]
+	if !ok {
+		return fmt.Errorf("record %q not found in zone %q", name, zone)
+	}
+	rc.recordID = ""
+	return nil
+}
+
+// Status reports the daemon's current IPs and cached zone/record state. It
+// implements control.Daemon.
+func (d *daemon) Status() control.Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := control.Status{
+		IPv4:  d.lastIPv4,
+		IPv6:  d.lastIPv6,
+		Zones: make(map[string]control.ZoneStatus, len(d.cache)),
+	}
+	for zoneName, zoneCache := range d.cache {
+		zs := control.ZoneStatus{Records: make(map[string]control.RecordStatus)}
+		for name, rc := range zoneCache {
+			if name == "__zone__" {
+				zs.ZoneID = rc.zoneID
+				continue
+			}
+			rs := control.RecordStatus{RecordID: rc.recordID}
+			if !rc.lastUpdate.IsZero() {
+				rs.LastUpdate = rc.lastUpdate.UTC().Format(time.RFC3339)
+			}
+			zs.Records[name] = rs
+		}
+		status.Zones[zoneName] = zs
+	}
+	return status
 }
 
 func main() {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
 	cfgPath := "config.toml"
 	if len(os.Args) > 1 {
 		cfgPath = os.Args[1]
@@ -69,8 +464,8 @@ func main() {
 		logError("cloudflare_api_token is required in config")
 		os.Exit(1)
 	}
-	if cfg.IPProvider == "" {
-		logError("ip_provider is required in config")
+	if len(cfg.IP.Providers) == 0 {
+		logError("At least one [[ip.providers]] must be defined in config")
 		os.Exit(1)
 	}
 	if len(cfg.Zones) == 0 {
@@ -78,105 +473,218 @@ func main() {
 		os.Exit(1)
 	}
 
-	interval := 300
-	if cfg.PollInterval > 0 {
-		interval = cfg.PollInterval
+	d, err := newDaemon(cfgPath, cfg)
+	if err != nil {
+		logError(fmt.Sprintf("Failed to start daemon: %s", err))
+		os.Exit(1)
 	}
 
-	for zi := range cfg.Zones {
-		for ri := range cfg.Zones[zi].Records {
-			if cfg.Zones[zi].Records[ri].Type == "" {
-				cfg.Zones[zi].Records[ri].Type = "A"
+	if cfg.Metrics.ListenAddr != "" {
+		go func() {
+			if err := metrics.Serve(cfg.Metrics.ListenAddr); err != nil {
+				logError(fmt.Sprintf("Metrics server exited: %s", err))
 			}
-		}
+		}()
+		logInfo(fmt.Sprintf("Serving metrics on %s", cfg.Metrics.ListenAddr))
 	}
 
-	cache := make(map[string]map[string]*recordCache)
-	for _, z := range cfg.Zones {
-		cache[z.Name] = make(map[string]*recordCache)
-		for _, r := range z.Records {
-			cache[z.Name][r.Name] = &recordCache{}
-		}
+	if cfg.Control.ListenAddr != "" {
+		srv := control.New(d, cfg.Control.Token)
+		go func() {
+			if err := http.ListenAndServe(cfg.Control.ListenAddr, srv.Handler()); err != nil {
+				logError(fmt.Sprintf("Control server exited: %s", err))
+			}
+		}()
+		logInfo(fmt.Sprintf("Serving control API on %s", cfg.Control.ListenAddr))
 	}
 
-	lastIP := ""
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGUSR1)
+	go func() {
+		for sig := range sigCh {
+			switch sig {
+			case syscall.SIGHUP:
+				if err := d.Reload(); err != nil {
+					logError(fmt.Sprintf("SIGHUP reload failed: %s", err))
+				}
+			case syscall.SIGUSR1:
+				logInfo("SIGUSR1 received, forcing sync")
+				d.Sync()
+			}
+		}
+	}()
 
+	d.run(context.Background())
+}
+
+// run is the poll loop: on each tick (or on-demand via syncCh) it checks
+// the public IP and reconciles every configured zone's records against it.
+func (d *daemon) run(ctx context.Context) {
+	force := false
 	for {
-		ip, err := getPublicIP(fmt.Sprintf("https://%s", cfg.IPProvider))
-		if err != nil {
-			logError(fmt.Sprintf("Failed to get public IP: %s", err))
-			logInfo(fmt.Sprintf("Checking again in %s", formatDuration(interval)))
-			time.Sleep(time.Duration(interval) * time.Second)
-			continue
+		select {
+		case <-d.syncCh:
+			force = true
+		default:
 		}
 
-		ipChanged := ip != lastIP
-		if ipChanged {
-			logInfo(fmt.Sprintf("Public IP changed to %s", ip))
-			lastIP = ip
+		d.poll(ctx, force)
+		force = false
+
+		interval := d.pollInterval()
+		logInfo(fmt.Sprintf("Checking again in %s", formatDuration(interval)))
+
+		select {
+		case <-time.After(time.Duration(interval) * time.Second):
+		case <-d.syncCh:
+			logInfo("Sync requested, skipping remaining wait")
+			force = true
+		}
+	}
+}
+
+func (d *daemon) pollInterval() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cfg.PollInterval > 0 {
+		return d.cfg.PollInterval
+	}
+	return 300
+}
+
+// poll runs one IP-check-and-reconcile cycle. If force is true, records are
+// updated even if the IP hasn't changed since the last cycle.
+func (d *daemon) poll(ctx context.Context, force bool) {
+	d.mu.Lock()
+	cfg := d.cfg
+	cf := d.cf
+	ipv4Resolver := d.ipv4Resolver
+	ipv6Resolver := d.ipv6Resolver
+	d.mu.Unlock()
+
+	ipv4, err := ipv4Resolver.Resolve(ctx, ipprovider.IPv4)
+	if err != nil {
+		metrics.IPCheckTotal.WithLabelValues("error").Inc()
+		logError(fmt.Sprintf("Failed to get public IPv4: %s", err))
+		return
+	}
+	metrics.IPCheckTotal.WithLabelValues("success").Inc()
+	metrics.SetIP("4", ipv4)
+
+	d.mu.Lock()
+	ipv4Changed := force || ipv4 != d.lastIPv4
+	if ipv4 != d.lastIPv4 {
+		logInfo(fmt.Sprintf("Public IPv4 changed to %s", ipv4))
+		d.lastIPv4 = ipv4
+	} else {
+		logInfo("IPv4 hasn't changed")
+	}
+	d.mu.Unlock()
+
+	var ipv6 string
+	var ipv6Changed bool
+	if ipv6Resolver != nil {
+		ipv6, err = ipv6Resolver.Resolve(ctx, ipprovider.IPv6)
+		if err != nil {
+			metrics.IPCheckTotal.WithLabelValues("error").Inc()
+			logError(fmt.Sprintf("Failed to get public IPv6: %s", err))
 		} else {
-			logInfo(fmt.Sprintf("IP hasn't changed"))
+			metrics.IPCheckTotal.WithLabelValues("success").Inc()
+			metrics.SetIP("6", ipv6)
+
+			d.mu.Lock()
+			ipv6Changed = force || ipv6 != d.lastIPv6
+			if ipv6 != d.lastIPv6 {
+				logInfo(fmt.Sprintf("Public IPv6 changed to %s", ipv6))
+				d.lastIPv6 = ipv6
+			} else {
+				logInfo("IPv6 hasn't changed")
+			}
+			d.mu.Unlock()
 		}
+	}
 
-		for _, zone := range cfg.Zones {
-			zoneCache := cache[zone.Name]
+	for _, zone := range cfg.Zones {
+		zoneID, ok := d.getZoneID(zone.Name)
+		if !ok {
+			// A concurrent reload dropped this zone's cache entry; it'll
+			// reappear (or not) the next time applyConfig runs.
+			continue
+		}
 
-			if zoneCache["__zone__"] == nil {
-				zoneCache["__zone__"] = &recordCache{}
+		if zoneID == "" {
+			lookedUp, err := cf.ZoneID(ctx, zone.Name)
+			if err != nil {
+				logError(fmt.Sprintf("[%s] Failed to get zone ID: %s", zone.Name, err))
+				continue
 			}
-			if zoneCache["__zone__"].zoneID == "" {
-				zoneID, err := getZoneID(cfg.CloudflareAPIToken, zone.Name)
-				if err != nil {
-					logError(fmt.Sprintf("[%s] Failed to get zone ID: %s", zone.Name, err))
-					continue
-				}
-				zoneCache["__zone__"].zoneID = zoneID
+			if !d.setZoneID(zone.Name, lookedUp) {
+				continue
 			}
-			zoneID := zoneCache["__zone__"].zoneID
+			zoneID = lookedUp
+		}
 
-			for _, record := range zone.Records {
-				isDynamic := record.Type == "A" || record.Type == "AAAA"
+		for _, record := range zone.Records {
+			isDynamic := record.Type == "A" || record.Type == "AAAA"
 
-				if isDynamic && !ipChanged {
-					continue
-				}
-				if !isDynamic && zoneCache[record.Name].recordID != "" {
+			var dynamicIP string
+			var dynamicChanged bool
+			if record.Type == "AAAA" {
+				if ipv6Resolver == nil {
 					continue
 				}
+				dynamicIP, dynamicChanged = ipv6, ipv6Changed
+			} else {
+				dynamicIP, dynamicChanged = ipv4, ipv4Changed
+			}
 
-				rc := zoneCache[record.Name]
-
-				if rc.recordID == "" {
-					recordID, err := getOrCreateRecord(
-						cfg.CloudflareAPIToken,
-						zoneID,
-						zone.Name,
-						record,
-						ip,
-					)
-					if err != nil {
-						logError(fmt.Sprintf("[%s] [%s] Failed to get/create record: %s", zone.Name, record.Name, err))
-						continue
-					}
-					rc.recordID = recordID
-				}
+			if isDynamic && !dynamicChanged {
+				continue
+			}
+
+			recordID, ok := d.getRecordID(zone.Name, record.Name)
+			if !ok {
+				continue
+			}
+			if !isDynamic && recordID != "" {
+				continue
+			}
 
-				if !isDynamic {
+			if recordID == "" {
+				newID, err := getOrCreateRecord(ctx, cf, zoneID, zone.Name, record, dynamicIP)
+				if err != nil {
+					logError(fmt.Sprintf("[%s] [%s] Failed to get/create record: %s", zone.Name, record.Name, err))
 					continue
 				}
-
-				if err := updateRecord(cfg.CloudflareAPIToken, zoneID, rc.recordID, zone.Name, record, ip); err != nil {
-					logError(fmt.Sprintf("[%s] [%s] Failed to update record: %s", zone.Name, record.Name, err))
-					rc.recordID = ""
+				if !d.setRecordID(zone.Name, record.Name, newID) {
 					continue
 				}
+				recordID = newID
+			}
+
+			if !isDynamic {
+				continue
+			}
 
-				logInfo(fmt.Sprintf("[%s] [%s %s] Updated to %s (proxied=%v)", zone.Name, record.Type, record.Name, ip, record.Proxied))
+			if err := cf.UpdateRecord(ctx, zoneID, recordID, record.toSpec(zone.Name, dynamicIP)); err != nil {
+				metrics.RecordUpdateTotal.WithLabelValues(zone.Name, record.Name, "error").Inc()
+				logError(fmt.Sprintf("[%s] [%s] Failed to update record: %s", zone.Name, record.Name, err))
+				d.setRecordID(zone.Name, record.Name, "")
+				continue
 			}
+			d.setRecordUpdated(zone.Name, record.Name, time.Now())
+			metrics.RecordUpdateSuccess(zone.Name, record.Name)
+
+			logInfo(fmt.Sprintf("[%s] [%s %s] Updated to %s (proxied=%v)", zone.Name, record.Type, record.Name, dynamicIP, record.Proxied))
 		}
 
-		logInfo(fmt.Sprintf("Checking again in %s", formatDuration(interval)))
-		time.Sleep(time.Duration(interval) * time.Second)
+		for _, tree := range zone.Dnsdisc {
+			if err := dnsdisc.Sync(ctx, cf, zoneID, zone.Name, tree.toTree()); err != nil {
+				logError(fmt.Sprintf("[%s] [dnsdisc %s] Failed to sync tree: %s", zone.Name, tree.Domain, err))
+				continue
+			}
+			logInfo(fmt.Sprintf("[%s] [dnsdisc %s] Tree in sync", zone.Name, tree.Domain))
+		}
 	}
 }
 
@@ -200,42 +708,6 @@ func formatDuration(seconds int) string {
 	return fmt.Sprintf("%dh %dm", hours, minutes)
 }
 
-func getPublicIP(provider string) (string, error) {
-	resp, err := http.Get(provider)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	buf := make([]byte, 64)
-	n, _ := resp.Body.Read(buf)
-	return string(bytes.TrimSpace(buf[:n])), nil
-}
-
-func getZoneID(token, zoneName string) (string, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/zones?name=%s", cfBaseURL, zoneName), nil)
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		Result []struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", err
-	}
-	if len(data.Result) == 0 {
-		return "", fmt.Errorf("zone %q not found", zoneName)
-	}
-	return data.Result[0].ID, nil
-}
-
 func recordFQDN(recordName, zoneName string) string {
 	if recordName == "@" {
 		return zoneName
@@ -243,111 +715,19 @@ func recordFQDN(recordName, zoneName string) string {
 	return recordName + "." + zoneName
 }
 
-func getOrCreateRecord(token, zoneID, zoneName string, record Record, ip string) (string, error) {
+// getOrCreateRecord looks the record up in Cloudflare by type and name and
+// returns its ID, creating it first if it doesn't exist yet.
+func getOrCreateRecord(ctx context.Context, cf *cfclient.Client, zoneID, zoneName string, record Record, ip string) (string, error) {
 	fqdn := recordFQDN(record.Name, zoneName)
 
-	req, _ := http.NewRequest(
-		"GET",
-		fmt.Sprintf("%s/zones/%s/dns_records?type=%s&name=%s", cfBaseURL, zoneID, record.Type, fqdn),
-		nil,
-	)
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
+	existing, err := cf.ListRecords(ctx, zoneID, record.Type, fqdn)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	var list struct {
-		Result []struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
-		return "", err
-	}
-	if len(list.Result) > 0 {
-		return list.Result[0].ID, nil
+	if len(existing) > 0 {
+		return existing[0].ID, nil
 	}
 
 	logInfo(fmt.Sprintf("Record %s %s not found, creating it...", record.Type, fqdn))
-
-	body := buildRecordBody(record.Type, fqdn, resolveContent(record, zoneName, ip), record.Proxied)
-	buf, _ := json.Marshal(body)
-
-	req, _ = http.NewRequest("POST", fmt.Sprintf("%s/zones/%s/dns_records", cfBaseURL, zoneID), bytes.NewReader(buf))
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("create record failed: %s", resp.Status)
-	}
-
-	var createResp struct {
-		Result struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
-		return "", err
-	}
-	return createResp.Result.ID, nil
-}
-
-func updateRecord(token, zoneID, recordID, zoneName string, record Record, ip string) error {
-	fqdn := recordFQDN(record.Name, zoneName)
-	body := buildRecordBody(record.Type, fqdn, resolveContent(record, zoneName, ip), record.Proxied)
-	buf, _ := json.Marshal(body)
-
-	req, _ := http.NewRequest(
-		"PUT",
-		fmt.Sprintf("%s/zones/%s/dns_records/%s", cfBaseURL, zoneID, recordID),
-		bytes.NewReader(buf),
-	)
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("update failed: %s", resp.Status)
-	}
-	return nil
-}
-
-func resolveContent(record Record, zoneName, ip string) string {
-	switch record.Type {
-	case "A", "AAAA":
-		return ip
-	default:
-		if record.Target != "" {
-			return record.Target
-		}
-		return zoneName
-	}
-}
-
-func buildRecordBody(recordType, name, content string, proxied bool) map[string]interface{} {
-	ttl := 300
-	if proxied {
-		ttl = 1
-	}
-	return map[string]interface{}{
-		"type":    recordType,
-		"name":    name,
-		"content": content,
-		"ttl":     ttl,
-		"proxied": proxied,
-	}
+	return cf.CreateRecord(ctx, zoneID, record.toSpec(zoneName, ip))
 }
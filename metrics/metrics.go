@@ -0,0 +1,82 @@
+// Package metrics holds go-cf-daemon's Prometheus collectors and the
+// /metrics HTTP endpoint, so the daemon is observable the same way
+// container-oriented DNS tooling like traefik or cloudflared is.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	IPCheckTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cfdaemon_ip_check_total",
+		Help: "Public IP discovery attempts, by result.",
+	}, []string{"result"})
+
+	ipCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cfdaemon_ip_current",
+		Help: "The currently published public IP, labeled by family and IP (1 for the active address).",
+	}, []string{"family", "ip"})
+
+	CloudflareAPIRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cfdaemon_cloudflare_api_requests_total",
+		Help: "Cloudflare API calls, by method and outcome status.",
+	}, []string{"method", "status"})
+
+	RecordUpdateTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cfdaemon_record_update_total",
+		Help: "DNS record update attempts, by zone, record and result.",
+	}, []string{"zone", "record", "result"})
+
+	LastSuccessTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cfdaemon_last_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successful update per zone/record.",
+	}, []string{"zone", "record"})
+
+	CloudflareRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cfdaemon_cloudflare_request_duration_seconds",
+		Help:    "Latency of Cloudflare API calls made through cfclient.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+var (
+	ipCurrentMu   sync.Mutex
+	ipCurrentSeen = map[string]string{} // family -> last published IP
+)
+
+// SetIP records ip as the currently published address for family, zeroing
+// out the gauge series for any previously published address so dashboards
+// don't show two "current" IPs at once.
+func SetIP(family, ip string) {
+	ipCurrentMu.Lock()
+	defer ipCurrentMu.Unlock()
+
+	if prev, ok := ipCurrentSeen[family]; ok && prev != ip {
+		ipCurrent.WithLabelValues(family, prev).Set(0)
+	}
+	ipCurrentSeen[family] = ip
+	ipCurrent.WithLabelValues(family, ip).Set(1)
+}
+
+// RecordUpdateSuccess bumps the update counter and the last-success gauge
+// together, since a successful update always means both move.
+func RecordUpdateSuccess(zone, record string) {
+	RecordUpdateTotal.WithLabelValues(zone, record, "success").Inc()
+	LastSuccessTimestamp.WithLabelValues(zone, record).Set(float64(time.Now().Unix()))
+}
+
+// Serve starts the /metrics HTTP endpoint on addr. It blocks and should be
+// run in its own goroutine; errors other than server shutdown are fatal to
+// that goroutine's caller to handle.
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, mux)
+}